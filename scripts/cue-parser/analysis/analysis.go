@@ -0,0 +1,362 @@
+// Package analysis extracts @type/@version parameter information from CUE
+// ASTs. It is shared by the cue-parser CLI (one-shot stdin/stdout mode) and
+// the LSP server (long-lived, incremental mode), so it must not depend on
+// either os.Stdin/stdout or any LSP types.
+package analysis
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+// ParameterInfo represents a parameter found in a CUE @type context
+type ParameterInfo struct {
+	Name    string `json:"name"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	// EndLine and EndColumn mark the end of the whole field (computed
+	// from ast.Field.End()), so callers needing a full range to
+	// highlight - not just the start position - don't have to re-parse.
+	EndLine   int    `json:"end_line"`
+	EndColumn int    `json:"end_column"`
+	Context   string `json:"context"`
+	Version   string `json:"version"`
+
+	// Path is the dotted/indexed path from the file root to this
+	// parameter, e.g. "top.layers[0].processor", so that identically
+	// named parameters at different nesting depths can be told apart.
+	Path string `json:"path"`
+
+	// ParentContext is the index into ParseResult.Contexts of the
+	// TypeContext this parameter belongs to, or nil if the parameter is
+	// not nested under any @type.
+	ParentContext *int `json:"parent_context,omitempty"`
+
+	// Doc is the leading "//" comment block directly above the field,
+	// with comment markers stripped and paragraph breaks preserved.
+	Doc string `json:"doc,omitempty"`
+	// LineComment is a trailing "//" comment on the field's own line.
+	LineComment string `json:"line_comment,omitempty"`
+
+	// InvalidName is true when Name fails isValidParameterName. Such
+	// fields are still reported (rather than dropped) so that a
+	// diagnostics consumer - the LSP server's publishDiagnostics, the
+	// validate subcommand - can flag them at their own position.
+	InvalidName bool `json:"invalid_name,omitempty"`
+}
+
+// TypeContext represents an @type declaration and its scope
+type TypeContext struct {
+	Type      string `json:"type"`
+	Version   string `json:"version"`
+	// Kind is one of KindLiteral, KindSelector or KindExpression,
+	// reflecting how Type was spelled in the source (a plain string, an
+	// identifier/selector chain like pkg.Type, or something else the
+	// validator must defer on).
+	Kind      string `json:"kind"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+
+	// ParentContext is the index into ParseResult.Contexts of the
+	// nearest enclosing TypeContext, or nil at the root.
+	ParentContext *int `json:"parent_context,omitempty"`
+
+	// Doc is the leading "//" comment block directly above the @type
+	// field, and LineComment is a trailing "//" comment on its line.
+	Doc         string `json:"doc,omitempty"`
+	LineComment string `json:"line_comment,omitempty"`
+}
+
+// ParseResult contains all the information we need for parameter validation
+type ParseResult struct {
+	Parameters []ParameterInfo `json:"parameters"`
+	Contexts   []TypeContext   `json:"contexts"`
+
+	// FileDoc is the file-level leading comment block, if any - the
+	// doc-comment equivalent of a package comment.
+	FileDoc string `json:"file_doc,omitempty"`
+}
+
+// scope tracks, during the traversal, which TypeContext currently encloses
+// the decls being visited and the dotted path that got us there.
+type scope struct {
+	ctxIndex int // index into result.Contexts, or -1 if no enclosing @type
+	path     string
+}
+
+func (s scope) parentPtr() *int {
+	if s.ctxIndex < 0 {
+		return nil
+	}
+	idx := s.ctxIndex
+	return &idx
+}
+
+// ExtractInfo walks the AST and extracts @type contexts and parameters.
+// Unlike a flat ast.Walk, this performs an explicit stack-based traversal so
+// that a parameter is always attributed to the *nearest* enclosing struct
+// that carries an @type, not merely the first struct-with-@type found
+// anywhere in the file.
+func ExtractInfo(f *ast.File) ParseResult {
+	var result ParseResult
+	result.FileDoc = fileDoc(f)
+	walkDecls(f.Decls, scope{ctxIndex: -1}, &result)
+	return result
+}
+
+// walkDecls visits one struct's worth of declarations (either ast.File.Decls
+// or an ast.StructLit's Elts - both are []ast.Decl). If this level carries
+// its own @type, it is pushed as a new TypeContext parented to parent, and
+// becomes the enclosing context for the fields and nested structs found
+// here.
+func walkDecls(decls []ast.Decl, parent scope, result *ParseResult) {
+	current := parent
+
+	if ctx := findTypeInDecls(decls); ctx != nil {
+		ctx.ParentContext = parent.parentPtr()
+		result.Contexts = append(result.Contexts, *ctx)
+		current = scope{ctxIndex: len(result.Contexts) - 1, path: parent.path}
+	}
+
+	for _, d := range decls {
+		switch decl := d.(type) {
+		case *ast.Field:
+			name, pos, ok := fieldName(decl)
+			if !ok || name == "@type" || name == "@version" {
+				continue
+			}
+			if len(name) > 0 && (name[0] == '_' || name[0] == '#') {
+				continue
+			}
+
+			path := joinPath(current.path, name)
+
+			// Only fields inside some @type context are parameters; a
+			// field with no enclosing context (e.g. the top-level
+			// "top" struct itself) isn't one, and must not be emitted
+			// - that matches the original CLI's stdin/stdout contract,
+			// which the VS Code extension already depends on.
+			if current.ctxIndex >= 0 {
+				doc, lineComment := fieldComments(decl)
+				endLine, endColumn := fieldEndPosition(decl, pos)
+				result.Parameters = append(result.Parameters, ParameterInfo{
+					Name:          name,
+					Line:          int(pos.Line()),
+					Column:        int(pos.Column()),
+					EndLine:       endLine,
+					EndColumn:     endColumn,
+					Context:       result.Contexts[current.ctxIndex].Type,
+					Version:       result.Contexts[current.ctxIndex].Version,
+					Path:          path,
+					ParentContext: current.parentPtr(),
+					Doc:           doc,
+					LineComment:   lineComment,
+					InvalidName:   !isValidParameterName(name),
+				})
+			}
+
+			walkValue(decl.Value, current, path, result)
+
+		case *ast.Comprehension:
+			// The comprehension's struct literal contributes fields at
+			// the same level as its siblings, so it keeps the current
+			// path rather than introducing a new segment.
+			walkValue(decl.Value, current, current.path, result)
+
+		case *ast.EmbedDecl:
+			walkValue(decl.Expr, current, current.path, result)
+		}
+	}
+}
+
+// walkValue recurses into struct and list literals reachable from a field
+// value (or comprehension/embedding), extending path as it goes.
+func walkValue(expr ast.Expr, current scope, path string, result *ParseResult) {
+	switch v := expr.(type) {
+	case *ast.StructLit:
+		if v == nil {
+			return
+		}
+		walkDecls(v.Elts, scope{ctxIndex: current.ctxIndex, path: path}, result)
+	case *ast.ListLit:
+		if v == nil {
+			return
+		}
+		for i, elt := range v.Elts {
+			walkValue(elt, current, fmt.Sprintf("%s[%d]", path, i), result)
+		}
+	}
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// fieldName extracts the plain (unquoted) name and position of a field
+// label, whether it's written as a quoted string or a bare identifier.
+func fieldName(field *ast.Field) (string, token.Pos, bool) {
+	if field == nil || field.Label == nil {
+		return "", token.NoPos, false
+	}
+	switch label := field.Label.(type) {
+	case *ast.BasicLit:
+		if label == nil {
+			return "", token.NoPos, false
+		}
+		name := label.Value
+		if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+			name = name[1 : len(name)-1]
+		}
+		return name, label.Pos(), true
+	case *ast.Ident:
+		if label == nil {
+			return "", token.NoPos, false
+		}
+		return label.Name, label.Pos(), true
+	default:
+		return "", token.NoPos, false
+	}
+}
+
+// fieldEndPosition returns a field's end line/column, computed from
+// ast.Field.End(). Malformed input (e.g. an unterminated struct) can produce
+// a Pos that reports as valid but falls outside the parsed file's range,
+// which panics deep inside cuelang's own Pos.Line()/Column(); recover and
+// fall back to the field's start position rather than let that panic
+// propagate out of the extractor.
+func fieldEndPosition(field *ast.Field, start token.Pos) (line, column int) {
+	defer func() {
+		if recover() != nil {
+			line, column = int(start.Line()), int(start.Column())
+		}
+	}()
+
+	endPos := field.End()
+	if !endPos.IsValid() {
+		return int(start.Line()), int(start.Column())
+	}
+	return int(endPos.Line()), int(endPos.Column())
+}
+
+// findTypeInDecls looks for an @type field among decls (either a struct
+// literal's Elts or a file's top-level Decls).
+func findTypeInDecls(decls []ast.Decl) *TypeContext {
+	var typeValue, versionValue, typeKind string
+	var typeLine, typeColumn int
+	var typeDoc, typeLineComment string
+
+	for _, d := range decls {
+		field, ok := d.(*ast.Field)
+		if !ok {
+			continue
+		}
+		label, ok := field.Label.(*ast.BasicLit)
+		if !ok {
+			continue
+		}
+		if label.Value == `"@type"` {
+			if value, kind, ok := decodeTypeValue(field.Value); ok {
+				typeValue = value
+				typeKind = kind
+				pos := label.Pos()
+				typeLine = int(pos.Line())
+				typeColumn = int(pos.Column())
+				typeDoc, typeLineComment = fieldComments(field)
+			}
+		} else if label.Value == `"@version"` {
+			if value, _, ok := decodeTypeValue(field.Value); ok {
+				versionValue = value
+			}
+		}
+	}
+
+	if typeValue == "" {
+		return nil
+	}
+
+	if versionValue == "" {
+		versionValue = "0.0.0" // Default version
+	}
+
+	startLine, endLine := declsLineRange(decls, typeLine)
+
+	return &TypeContext{
+		Type:        typeValue,
+		Version:     versionValue,
+		Kind:        typeKind,
+		Line:        typeLine,
+		Column:      typeColumn,
+		StartLine:   startLine,
+		EndLine:     endLine,
+		Doc:         typeDoc,
+		LineComment: typeLineComment,
+	}
+}
+
+// declsLineRange computes the enclosing line range for a set of decls from
+// the decls' own positions rather than from braces, so it also works for a
+// file's top-level Decls, which have no Lbrace/Rbrace of their own. It
+// falls back to typeLine if no decl carries a usable position.
+func declsLineRange(decls []ast.Decl, typeLine int) (int, int) {
+	start, end := -1, -1
+	for _, d := range decls {
+		pos := d.Pos()
+		if !pos.IsValid() {
+			continue
+		}
+		line := int(pos.Line())
+		if start == -1 || line < start {
+			start = line
+		}
+		endPos := d.End()
+		endLine := line
+		if endPos.IsValid() {
+			endLine = int(endPos.Line())
+		}
+		if endLine > end {
+			end = endLine
+		}
+	}
+	if start == -1 {
+		start = typeLine
+	}
+	if end == -1 {
+		end = typeLine
+	}
+	return start, end
+}
+
+// isValidParameterName checks if a name follows zetta_utils parameter naming rules
+func isValidParameterName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	// Allow @mode as special case
+	if name == "@mode" {
+		return true
+	}
+
+	// Must start with letter or underscore (but we exclude _ fields elsewhere)
+	first := name[0]
+	if !((first >= 'a' && first <= 'z') || (first >= 'A' && first <= 'Z') || first == '_') {
+		return false
+	}
+
+	// Rest must be alphanumeric or underscore
+	for i := 1; i < len(name); i++ {
+		c := name[i]
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_') {
+			return false
+		}
+	}
+
+	return true
+}
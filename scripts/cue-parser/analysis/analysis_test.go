@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/parser"
+)
+
+func mustExtract(t *testing.T, src string) ParseResult {
+	t.Helper()
+	f, err := parser.ParseFile("test.cue", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	return ExtractInfo(f)
+}
+
+func contextByType(result ParseResult, typ string) *TypeContext {
+	for i := range result.Contexts {
+		if result.Contexts[i].Type == typ {
+			return &result.Contexts[i]
+		}
+	}
+	return nil
+}
+
+func paramByPath(result ParseResult, path string) *ParameterInfo {
+	for i := range result.Parameters {
+		if result.Parameters[i].Path == path {
+			return &result.Parameters[i]
+		}
+	}
+	return nil
+}
+
+func TestExtractInfo_StructInStruct(t *testing.T) {
+	src := `
+top: {
+	"@type": "Outer"
+	name: "a"
+	inner: {
+		"@type": "Inner"
+		value: 1
+	}
+}
+`
+	result := mustExtract(t, src)
+
+	outer := contextByType(result, "Outer")
+	inner := contextByType(result, "Inner")
+	if outer == nil || inner == nil {
+		t.Fatalf("expected both Outer and Inner contexts, got %+v", result.Contexts)
+	}
+	if outer.ParentContext != nil {
+		t.Errorf("Outer should have no parent context, got %v", *outer.ParentContext)
+	}
+	if inner.ParentContext == nil || result.Contexts[*inner.ParentContext].Type != "Outer" {
+		t.Errorf("Inner should be parented to Outer, got %+v", inner.ParentContext)
+	}
+
+	name := paramByPath(result, "top.name")
+	if name == nil || name.Context != "Outer" {
+		t.Fatalf("expected top.name to belong to Outer, got %+v", name)
+	}
+	value := paramByPath(result, "top.inner.value")
+	if value == nil || value.Context != "Inner" {
+		t.Fatalf("expected top.inner.value to belong to Inner, got %+v", value)
+	}
+}
+
+func TestExtractInfo_ListOfStructs(t *testing.T) {
+	src := `
+top: {
+	"@type": "Pipeline"
+	layers: [
+		{
+			"@type": "Processor"
+			mode: "fast"
+		},
+		{
+			"@type": "Processor"
+			mode: "slow"
+		},
+	]
+}
+`
+	result := mustExtract(t, src)
+
+	if len(result.Contexts) != 3 {
+		t.Fatalf("expected 3 contexts (Pipeline + 2 Processor), got %d: %+v", len(result.Contexts), result.Contexts)
+	}
+
+	fast := paramByPath(result, "top.layers[0].mode")
+	slow := paramByPath(result, "top.layers[1].mode")
+	if fast == nil || slow == nil {
+		t.Fatalf("expected params at top.layers[0].mode and top.layers[1].mode, got %+v", result.Parameters)
+	}
+	if fast.Context != "Processor" || slow.Context != "Processor" {
+		t.Errorf("expected both list elements to belong to Processor context, got %+v and %+v", fast, slow)
+	}
+	if fast.ParentContext == nil || slow.ParentContext == nil || *fast.ParentContext == *slow.ParentContext {
+		t.Errorf("expected the two list elements to be attributed to distinct Processor contexts, got %+v and %+v", fast, slow)
+	}
+}
+
+func TestExtractInfo_ComprehensionStructValue(t *testing.T) {
+	src := `
+top: {
+	"@type": "Outer"
+	child: {
+		"@type": "Child"
+		count: 3
+	}
+	for i, x in [1, 2] {
+		generated: {
+			"@type": "Generated"
+			val: x
+		}
+	}
+}
+`
+	result := mustExtract(t, src)
+
+	generated := contextByType(result, "Generated")
+	if generated == nil {
+		t.Fatalf("expected a Generated context from the comprehension's struct value, got %+v", result.Contexts)
+	}
+	if generated.ParentContext == nil || result.Contexts[*generated.ParentContext].Type != "Outer" {
+		t.Errorf("Generated should be parented to Outer, got %+v", generated.ParentContext)
+	}
+
+	val := paramByPath(result, "top.generated.val")
+	if val == nil || val.Context != "Generated" {
+		t.Fatalf("expected top.generated.val to belong to Generated, got %+v", val)
+	}
+}
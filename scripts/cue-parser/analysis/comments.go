@@ -0,0 +1,107 @@
+package analysis
+
+import "cuelang.org/go/cue/ast"
+
+// fieldComments splits the comment groups attached to field into a leading
+// doc-comment (the block above the field) and a trailing line comment (text
+// after the field on the same source line). CUE attaches both kinds to a
+// node's Comments(), distinguished only by position, so we classify them by
+// comparing each group's line to the field's own line.
+func fieldComments(field *ast.Field) (doc string, lineComment string) {
+	fieldLine := int(field.Pos().Line())
+
+	var docParts, lineParts []string
+	for _, cg := range ast.Comments(field) {
+		if cg == nil || len(cg.List) == 0 {
+			continue
+		}
+		text := normalizeCommentGroup(cg)
+		if text == "" {
+			continue
+		}
+		if int(cg.Pos().Line()) == fieldLine {
+			lineParts = append(lineParts, text)
+		} else {
+			docParts = append(docParts, text)
+		}
+	}
+
+	return joinCommentParts(docParts), joinCommentParts(lineParts)
+}
+
+// normalizeCommentGroup turns a CommentGroup's raw "// ..." lines into plain
+// text: each line loses its "//" marker and a single following space, and
+// trailing whitespace is trimmed. Blank comment lines become blank lines in
+// the result, which preserves paragraph breaks in multi-line doc comments.
+func normalizeCommentGroup(cg *ast.CommentGroup) string {
+	lines := make([]string, 0, len(cg.List))
+	for _, c := range cg.List {
+		line := stripCommentMarker(c.Text)
+		lines = append(lines, line)
+	}
+	return trimTrailingBlankLines(lines)
+}
+
+func stripCommentMarker(text string) string {
+	line := text
+	if len(line) >= 2 && line[0] == '/' && line[1] == '/' {
+		line = line[2:]
+	}
+	if len(line) > 0 && line[0] == ' ' {
+		line = line[1:]
+	}
+	for len(line) > 0 {
+		last := line[len(line)-1]
+		if last == ' ' || last == '\t' || last == '\r' {
+			line = line[:len(line)-1]
+			continue
+		}
+		break
+	}
+	return line
+}
+
+func trimTrailingBlankLines(lines []string) string {
+	end := len(lines)
+	for end > 0 && lines[end-1] == "" {
+		end--
+	}
+	out := ""
+	for i, line := range lines[:end] {
+		if i > 0 {
+			out += "\n"
+		}
+		out += line
+	}
+	return out
+}
+
+func joinCommentParts(parts []string) string {
+	out := ""
+	for i, p := range parts {
+		if i > 0 {
+			out += "\n\n"
+		}
+		out += p
+	}
+	return out
+}
+
+// fileDoc collects the leading comment group(s) of the file - the
+// file-level doc comment that appears before the first declaration - into a
+// single normalized string.
+func fileDoc(f *ast.File) string {
+	var parts []string
+	for _, cg := range ast.Comments(f) {
+		if cg == nil || len(cg.List) == 0 {
+			continue
+		}
+		if len(f.Decls) > 0 && cg.Pos().Line() >= f.Decls[0].Pos().Line() {
+			continue
+		}
+		if text := normalizeCommentGroup(cg); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return joinCommentParts(parts)
+}
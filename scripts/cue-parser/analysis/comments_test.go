@@ -0,0 +1,45 @@
+package analysis
+
+import "testing"
+
+func TestExtractInfo_Comments(t *testing.T) {
+	src := `// File level doc.
+//
+// Second paragraph.
+
+top: {
+	// @type doc comment.
+	"@type": "Outer" // trailing on @type
+
+	// name is the thing's name.
+	name: "a" // trailing on name
+}
+`
+	result := mustExtract(t, src)
+
+	if result.FileDoc != "File level doc.\n\nSecond paragraph." {
+		t.Errorf("FileDoc = %q", result.FileDoc)
+	}
+
+	ctx := contextByType(result, "Outer")
+	if ctx == nil {
+		t.Fatalf("expected Outer context, got %+v", result.Contexts)
+	}
+	if ctx.Doc != "@type doc comment." {
+		t.Errorf("Context Doc = %q", ctx.Doc)
+	}
+	if ctx.LineComment != "trailing on @type" {
+		t.Errorf("Context LineComment = %q", ctx.LineComment)
+	}
+
+	name := paramByPath(result, "top.name")
+	if name == nil {
+		t.Fatalf("expected top.name parameter, got %+v", result.Parameters)
+	}
+	if name.Doc != "name is the thing's name." {
+		t.Errorf("Param Doc = %q", name.Doc)
+	}
+	if name.LineComment != "trailing on name" {
+		t.Errorf("Param LineComment = %q", name.LineComment)
+	}
+}
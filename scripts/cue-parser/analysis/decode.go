@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"strconv"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+// Value kinds for a decoded @type/@version expression. A TypeContext's Kind
+// tells the downstream validator whether Type is a name it can look up in a
+// schema directly (literal) or must defer on (selector, expression).
+const (
+	KindLiteral    = "literal"
+	KindSelector   = "selector"
+	KindExpression = "expression"
+)
+
+// decodeTypeValue resolves a field's value expression to a canonical string
+// plus the kind of expression it came from. It handles:
+//   - plain string literals, via strconv.Unquote with a manual-trim fallback
+//     for CUE string forms Unquote doesn't understand (raw/multiline strings)
+//   - string concatenation ("my" + "type"), folded recursively
+//   - parenthesized expressions
+//   - bare identifiers and selector chains (#SomeType, pkg.Type), emitted as
+//     the dotted path unchanged so schemas can key on it later
+//
+// Interpolations (`"\(x)"`) and anything else unresolvable at parse time
+// come back as ("", KindExpression, false).
+func decodeTypeValue(expr ast.Expr) (value string, kind string, ok bool) {
+	switch v := expr.(type) {
+	case *ast.ParenExpr:
+		return decodeTypeValue(v.X)
+
+	case *ast.BasicLit:
+		s, ok := unquoteStringLit(v.Value)
+		if !ok {
+			return "", KindExpression, false
+		}
+		return s, KindLiteral, true
+
+	case *ast.Ident:
+		return v.Name, KindSelector, true
+
+	case *ast.SelectorExpr:
+		base, _, ok := decodeTypeValue(v.X)
+		if !ok {
+			return "", KindExpression, false
+		}
+		sel, ok := v.Sel.(*ast.Ident)
+		if !ok {
+			return "", KindExpression, false
+		}
+		return base + "." + sel.Name, KindSelector, true
+
+	case *ast.BinaryExpr:
+		if v.Op != token.ADD {
+			return "", KindExpression, false
+		}
+		left, leftKind, ok := decodeTypeValue(v.X)
+		if !ok {
+			return "", KindExpression, false
+		}
+		right, rightKind, ok := decodeTypeValue(v.Y)
+		if !ok {
+			return "", KindExpression, false
+		}
+		combined := left + right
+		if leftKind == KindLiteral && rightKind == KindLiteral {
+			return combined, KindLiteral, true
+		}
+		return combined, KindExpression, true
+
+	default:
+		return "", KindExpression, false
+	}
+}
+
+// unquoteStringLit strips the surrounding quotes from a CUE string literal's
+// raw token text. It tries strconv.Unquote first since it correctly handles
+// escape sequences; CUE forms Unquote rejects (raw strings like #"..."#,
+// multiline """ blocks) fall back to a plain trim of the outermost quote
+// characters.
+func unquoteStringLit(raw string) (string, bool) {
+	if s, err := strconv.Unquote(raw); err == nil {
+		return s, true
+	}
+
+	trimmed := raw
+	for len(trimmed) > 0 && trimmed[0] == '#' {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+	if len(trimmed) >= 6 && trimmed[:3] == `"""` && trimmed[len(trimmed)-3:] == `"""` {
+		return trimmed[3 : len(trimmed)-3], true
+	}
+	if len(trimmed) >= 2 && trimmed[0] == '"' && trimmed[len(trimmed)-1] == '"' {
+		return trimmed[1 : len(trimmed)-1], true
+	}
+
+	return "", false
+}
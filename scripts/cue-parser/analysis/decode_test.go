@@ -0,0 +1,53 @@
+package analysis
+
+import "testing"
+
+func TestExtractInfo_TypeValueForms(t *testing.T) {
+	cases := []struct {
+		name     string
+		src      string
+		wantType string
+		wantKind string
+	}{
+		{
+			name:     "plain string",
+			src:      `top: {"@type": "MyOp"}`,
+			wantType: "MyOp",
+			wantKind: KindLiteral,
+		},
+		{
+			name:     "string concatenation",
+			src:      `top: {"@type": "my" + "type"}`,
+			wantType: "mytype",
+			wantKind: KindLiteral,
+		},
+		{
+			name:     "identifier reference",
+			src:      `#SomeType: {}` + "\n" + `top: {"@type": #SomeType}`,
+			wantType: "#SomeType",
+			wantKind: KindSelector,
+		},
+		{
+			name:     "selector reference",
+			src:      `top: {"@type": pkg.Type}`,
+			wantType: "pkg.Type",
+			wantKind: KindSelector,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := mustExtract(t, tc.src)
+			if len(result.Contexts) != 1 {
+				t.Fatalf("expected exactly one context, got %+v", result.Contexts)
+			}
+			ctx := result.Contexts[0]
+			if ctx.Type != tc.wantType {
+				t.Errorf("Type = %q, want %q", ctx.Type, tc.wantType)
+			}
+			if ctx.Kind != tc.wantKind {
+				t.Errorf("Kind = %q, want %q", ctx.Kind, tc.wantKind)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package analysis
+
+import "testing"
+
+func TestExtractInfo_InvalidParameterNameIsReportedNotDropped(t *testing.T) {
+	src := `
+top: {
+	"@type": "Outer"
+	"1bad": "x"
+}
+`
+	result := mustExtract(t, src)
+
+	param := paramByPath(result, "top.1bad")
+	if param == nil {
+		t.Fatalf("expected the invalid-name parameter to still be reported, got %+v", result.Parameters)
+	}
+	if !param.InvalidName {
+		t.Errorf("expected InvalidName = true for %q", param.Name)
+	}
+}
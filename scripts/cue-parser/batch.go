@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue/parser"
+
+	"github.com/ZettaAI/vscode-zutils/scripts/cue-parser/analysis"
+)
+
+// manifestEntry is one line of a --batch manifest: a file path and an
+// optional module version, tab-separated.
+type manifestEntry struct {
+	path          string
+	moduleVersion string
+}
+
+// batchResult is the NDJSON record emitted for each manifest entry.
+// ModuleVersion echoes the manifest's optional second column unchanged, so a
+// consumer can correlate path -> version without re-reading the manifest.
+type batchResult struct {
+	Path          string                `json:"path"`
+	ModuleVersion string                `json:"module_version,omitempty"`
+	Result        *analysis.ParseResult `json:"result,omitempty"`
+	Errors        []string              `json:"errors,omitempty"`
+}
+
+// runBatch parses every file listed in manifestPath and writes one JSON
+// object per line to stdout, in manifest order, continuing past per-file
+// syntax errors instead of exiting. Up to jobs files are parsed
+// concurrently; output order is still keyed by manifest line number.
+func runBatch(manifestPath string, jobs int) {
+	entries, err := readManifest(manifestPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading manifest %s: %v\n", manifestPath, err)
+		os.Exit(1)
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]batchResult, len(entries))
+
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, entry manifestEntry) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = parseManifestEntry(entry)
+		}(i, entry)
+	}
+	wg.Wait()
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	enc := json.NewEncoder(out)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshaling result for %s: %v\n", r.Path, err)
+		}
+	}
+}
+
+// readManifest parses a plain-text manifest with one "path\tmoduleVersion"
+// (or just "path") entry per line. Blank lines and "#"-prefixed comment
+// lines are skipped.
+func readManifest(manifestPath string) ([]manifestEntry, error) {
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []manifestEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 2)
+		entry := manifestEntry{path: parts[0]}
+		if len(parts) == 2 {
+			entry.moduleVersion = strings.TrimSpace(parts[1])
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseManifestEntry parses a single file from disk the same way runOnce
+// parses stdin, but never exits the process on error - it reports the
+// problem back as part of the batch result instead.
+func parseManifestEntry(entry manifestEntry) batchResult {
+	result := batchResult{Path: entry.path, ModuleVersion: entry.moduleVersion}
+
+	content, err := os.ReadFile(entry.path)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	f, err := parser.ParseFile(entry.path, content, parser.ParseComments)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	if f == nil {
+		return result
+	}
+
+	parsed := analysis.ExtractInfo(f)
+	result.Result = &parsed
+	return result
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.txt")
+	content := "# comment\na.cue\tv1.2.3\n\nb.cue\n"
+	if err := os.WriteFile(manifestPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	entries, err := readManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("readManifest: %v", err)
+	}
+
+	want := []manifestEntry{
+		{path: "a.cue", moduleVersion: "v1.2.3"},
+		{path: "b.cue"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestParseManifestEntry_ContinuesPastSyntaxErrors(t *testing.T) {
+	dir := t.TempDir()
+	goodPath := filepath.Join(dir, "good.cue")
+	badPath := filepath.Join(dir, "bad.cue")
+	if err := os.WriteFile(goodPath, []byte(`top: {"@type": "Foo"}`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(badPath, []byte(`top: {`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	good := parseManifestEntry(manifestEntry{path: goodPath, moduleVersion: "v1.2.3"})
+	if good.Result == nil || len(good.Errors) != 0 {
+		t.Errorf("good file: Result = %+v, Errors = %v", good.Result, good.Errors)
+	}
+	if good.ModuleVersion != "v1.2.3" {
+		t.Errorf("good file: ModuleVersion = %q, want %q", good.ModuleVersion, "v1.2.3")
+	}
+
+	bad := parseManifestEntry(manifestEntry{path: badPath})
+	if len(bad.Errors) == 0 {
+		t.Errorf("bad file: expected a reported error, got none")
+	}
+}
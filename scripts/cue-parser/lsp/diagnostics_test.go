@@ -0,0 +1,48 @@
+package lsp
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/parser"
+
+	"github.com/ZettaAI/vscode-zutils/scripts/cue-parser/analysis"
+)
+
+func TestDiagnosticsForParseResult_InvalidParameterName(t *testing.T) {
+	src := `
+top: {
+	"@type": "Outer"
+	"1bad": "x"
+}
+`
+	f, err := parser.ParseFile("test.cue", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	result := analysis.ExtractInfo(f)
+
+	diags := diagnosticsForParseResult(result, src)
+
+	found := false
+	for _, d := range diags {
+		if d.Severity == 1 && d.Message == `"1bad" is not a valid parameter name` {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected an invalid-parameter-name diagnostic, got %+v", diags)
+	}
+}
+
+func TestUTF16FieldPosition_MultibyteLinePrefix(t *testing.T) {
+	// "日本語" is 3 runes / 9 bytes, each encoding to a single UTF-16 code
+	// unit. A field label at byte column 10 on this line (1-based, right
+	// after the 9-byte prefix) sits at UTF-16 character 3, not 9.
+	lines := []string{`日本語"1bad"`}
+
+	got := utf16FieldPosition(lines, 1, 10)
+	want := map[string]int{"line": 0, "character": 3}
+	if got["line"] != want["line"] || got["character"] != want["character"] {
+		t.Errorf("utf16FieldPosition(lines, 1, 10) = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,604 @@
+// Package lsp implements a minimal Language Server Protocol server over
+// stdio for CUE @type parameter validation. It keeps one persistent
+// document store so the VS Code extension can talk to a single process
+// instead of forking cue-parser per keystroke.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"cuelang.org/go/cue/parser"
+
+	"github.com/ZettaAI/vscode-zutils/scripts/cue-parser/analysis"
+)
+
+// document is everything the server keeps per open file.
+type document struct {
+	uri     string
+	text    string
+	version int
+	result  analysis.ParseResult
+}
+
+// Server is a long-lived LSP server speaking JSON-RPC 2.0 over stdio.
+type Server struct {
+	mu   sync.Mutex
+	docs map[string]*document
+
+	// schemaPath is an optional JSON file (from initializationOptions),
+	// and schema is what loading it produced - nil if schemaPath is unset
+	// or failed to load, in which case completion falls back to proposing
+	// names already observed in the document.
+	schemaPath string
+	schema     Schema
+
+	out *bufio.Writer
+	log *log.Logger
+}
+
+// NewServer constructs a Server with an empty document store.
+func NewServer(logger *log.Logger) *Server {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	return &Server{
+		docs: make(map[string]*document),
+		log:  logger,
+	}
+}
+
+// rpcMessage is the wire shape shared by requests, responses and
+// notifications. Exactly one of Method (request/notification) or Result/Error
+// (response) is populated at a time.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Run reads Content-Length framed JSON-RPC messages from r, dispatches them,
+// and writes responses/notifications to w until the client sends `exit` or r
+// is closed.
+func (s *Server) Run(r io.Reader, w io.Writer) error {
+	s.out = bufio.NewWriter(w)
+	reader := bufio.NewReader(r)
+
+	for {
+		msg, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("lsp: reading message: %w", err)
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		s.dispatch(msg)
+	}
+}
+
+func readMessage(r *bufio.Reader) (*rpcMessage, error) {
+	contentLength := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(strings.ToLower(line), "content-length:") {
+			v := strings.TrimSpace(line[len("content-length:"):])
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length %q: %w", v, err)
+			}
+			contentLength = n
+		}
+	}
+	if contentLength < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	var msg rpcMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("invalid JSON-RPC message: %w", err)
+	}
+	return &msg, nil
+}
+
+func (s *Server) send(msg rpcMessage) {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		s.log.Printf("lsp: marshal outgoing message: %v", err)
+		return
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body))
+	s.out.Write(body)
+	s.out.Flush()
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}, err *rpcError) {
+	s.send(rpcMessage{ID: id, Result: result, Error: err})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		s.log.Printf("lsp: marshal params for %s: %v", method, err)
+		return
+	}
+	s.send(rpcMessage{Method: method, Params: raw})
+}
+
+func (s *Server) dispatch(msg *rpcMessage) {
+	switch msg.Method {
+	case "initialize":
+		s.handleInitialize(msg)
+	case "initialized", "$/setTrace":
+		// no-op notifications
+	case "shutdown":
+		s.reply(msg.ID, nil, nil)
+	case "textDocument/didOpen":
+		s.handleDidOpen(msg)
+	case "textDocument/didChange":
+		s.handleDidChange(msg)
+	case "textDocument/didClose":
+		s.handleDidClose(msg)
+	case "textDocument/documentSymbol":
+		s.handleDocumentSymbol(msg)
+	case "textDocument/hover":
+		s.handleHover(msg)
+	case "textDocument/completion":
+		s.handleCompletion(msg)
+	default:
+		if msg.ID != nil {
+			s.reply(msg.ID, nil, &rpcError{Code: -32601, Message: "method not found: " + msg.Method})
+		}
+	}
+}
+
+type initializeParams struct {
+	InitializationOptions struct {
+		SchemaPath string `json:"schemaPath"`
+	} `json:"initializationOptions"`
+}
+
+func (s *Server) handleInitialize(msg *rpcMessage) {
+	var params initializeParams
+	if len(msg.Params) > 0 {
+		_ = json.Unmarshal(msg.Params, &params)
+	}
+	schemaPath := params.InitializationOptions.SchemaPath
+	var schema Schema
+	if schemaPath != "" {
+		loaded, err := loadSchema(schemaPath)
+		if err != nil {
+			s.log.Printf("lsp: failed to load schema %s: %v", schemaPath, err)
+		} else {
+			schema = loaded
+		}
+	}
+
+	s.mu.Lock()
+	s.schemaPath = schemaPath
+	s.schema = schema
+	s.mu.Unlock()
+
+	s.reply(msg.ID, map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":   1, // full document sync
+			"documentSymbolProvider": true,
+			"hoverProvider":           true,
+			"completionProvider":      map[string]interface{}{"triggerCharacters": []string{"\""}},
+		},
+	}, nil)
+}
+
+type textDocumentItem struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+	Text    string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+func (s *Server) handleDidOpen(msg *rpcMessage) {
+	var params didOpenParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		s.log.Printf("lsp: didOpen: %v", err)
+		return
+	}
+	s.updateDocument(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+}
+
+type versionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+type contentChange struct {
+	Text string `json:"text"`
+}
+
+type didChangeParams struct {
+	TextDocument   versionedTextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []contentChange                 `json:"contentChanges"`
+}
+
+func (s *Server) handleDidChange(msg *rpcMessage) {
+	var params didChangeParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		s.log.Printf("lsp: didChange: %v", err)
+		return
+	}
+	if len(params.ContentChanges) == 0 {
+		return
+	}
+	// We only advertise full-document sync, so the last change carries the
+	// complete text.
+	text := params.ContentChanges[len(params.ContentChanges)-1].Text
+	s.updateDocument(params.TextDocument.URI, params.TextDocument.Version, text)
+}
+
+type didCloseParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+}
+
+func (s *Server) handleDidClose(msg *rpcMessage) {
+	var params didCloseParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.docs, params.TextDocument.URI)
+	s.mu.Unlock()
+}
+
+// updateDocument re-runs analysis.ExtractInfo and publishes fresh
+// diagnostics for uri.
+func (s *Server) updateDocument(uri string, version int, text string) {
+	f, err := parser.ParseFile(uri, text, parser.ParseComments)
+
+	doc := &document{uri: uri, text: text, version: version}
+	var diags []diagnostic
+	if err != nil {
+		diags = append(diags, diagnostic{
+			Range:    diagnosticRangeForWholeFile(text),
+			Severity: 1, // Error
+			Message:  err.Error(),
+		})
+	}
+	if f != nil {
+		doc.result = analysis.ExtractInfo(f)
+		diags = append(diags, diagnosticsForParseResult(doc.result, text)...)
+	}
+
+	s.mu.Lock()
+	s.docs[uri] = doc
+	s.mu.Unlock()
+
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"version":     version,
+		"diagnostics": diags,
+	})
+}
+
+func (s *Server) handleDocumentSymbol(msg *rpcMessage) {
+	uri, ok := s.docURIFromParams(msg.Params)
+	if !ok {
+		s.reply(msg.ID, []interface{}{}, nil)
+		return
+	}
+	s.mu.Lock()
+	doc := s.docs[uri]
+	s.mu.Unlock()
+	if doc == nil {
+		s.reply(msg.ID, []interface{}{}, nil)
+		return
+	}
+	s.reply(msg.ID, documentSymbols(doc), nil)
+}
+
+type positionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position struct {
+		Line      int `json:"line"`
+		Character int `json:"character"`
+	} `json:"position"`
+}
+
+func (s *Server) handleHover(msg *rpcMessage) {
+	var params positionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		s.reply(msg.ID, nil, nil)
+		return
+	}
+	s.mu.Lock()
+	doc := s.docs[params.TextDocument.URI]
+	s.mu.Unlock()
+	if doc == nil {
+		s.reply(msg.ID, nil, nil)
+		return
+	}
+
+	line := params.Position.Line + 1 // analysis lines are 1-based
+	ctx := enclosingContext(doc.result, line)
+	if ctx == nil {
+		s.reply(msg.ID, nil, nil)
+		return
+	}
+	s.reply(msg.ID, map[string]interface{}{
+		"contents": map[string]interface{}{
+			"kind":  "markdown",
+			"value": fmt.Sprintf("**%s**@%s", ctx.Type, ctx.Version),
+		},
+	}, nil)
+}
+
+func (s *Server) handleCompletion(msg *rpcMessage) {
+	var params positionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		s.reply(msg.ID, []interface{}{}, nil)
+		return
+	}
+	s.mu.Lock()
+	doc := s.docs[params.TextDocument.URI]
+	s.mu.Unlock()
+	if doc == nil {
+		s.reply(msg.ID, []interface{}{}, nil)
+		return
+	}
+
+	line := params.Position.Line + 1
+	ctx := enclosingContext(doc.result, line)
+	if ctx == nil {
+		s.reply(msg.ID, []interface{}{}, nil)
+		return
+	}
+
+	s.mu.Lock()
+	schema := s.schema
+	s.mu.Unlock()
+
+	seen := map[string]bool{}
+	var items []map[string]interface{}
+	if schema != nil {
+		// A schema is configured: propose its declared parameter names for
+		// this type@version rather than only what's already in the
+		// document, which is the whole point of completion. A type@version
+		// missing from the schema proposes nothing here; that's the same
+		// "unknown-type" case the validate subcommand already flags.
+		if schemaType, ok := schema[schemaKey(ctx.Type, ctx.Version)]; ok {
+			for name := range schemaType.Parameters {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				items = append(items, map[string]interface{}{
+					"label": name,
+					"kind":  10, // Property
+				})
+			}
+		}
+	} else {
+		for _, p := range doc.result.Parameters {
+			if p.Context != ctx.Type || p.Version != ctx.Version || seen[p.Name] {
+				continue
+			}
+			seen[p.Name] = true
+			items = append(items, map[string]interface{}{
+				"label": p.Name,
+				"kind":  10, // Property
+			})
+		}
+	}
+	s.reply(msg.ID, items, nil)
+}
+
+// Schema maps "type@version" to the parameters that @type context allows.
+// It mirrors the shape the `validate` subcommand's --schema flag consumes,
+// since completion only needs the parameter names, not Required/Type.
+type Schema map[string]schemaType
+
+type schemaType struct {
+	Parameters map[string]struct{} `json:"parameters"`
+}
+
+func loadSchema(path string) (Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+	return schema, nil
+}
+
+func schemaKey(typ, version string) string {
+	return typ + "@" + version
+}
+
+func (s *Server) docURIFromParams(params json.RawMessage) (string, bool) {
+	var p struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.TextDocument.URI == "" {
+		return "", false
+	}
+	return p.TextDocument.URI, true
+}
+
+// enclosingContext returns the innermost TypeContext whose line range
+// contains line (1-based), preferring the narrowest match.
+func enclosingContext(result analysis.ParseResult, line int) *analysis.TypeContext {
+	var best *analysis.TypeContext
+	for i := range result.Contexts {
+		c := &result.Contexts[i]
+		if line < c.StartLine || line > c.EndLine {
+			continue
+		}
+		if best == nil || (c.EndLine-c.StartLine) < (best.EndLine-best.StartLine) {
+			best = c
+		}
+	}
+	return best
+}
+
+func documentSymbols(doc *document) []map[string]interface{} {
+	symbols := make([]map[string]interface{}, 0, len(doc.result.Contexts))
+	for _, c := range doc.result.Contexts {
+		rng := lineRange(c.StartLine, c.EndLine)
+		symbols = append(symbols, map[string]interface{}{
+			"name":           fmt.Sprintf("%s@%s", c.Type, c.Version),
+			"kind":           23, // Struct
+			"range":          rng,
+			"selectionRange": rng,
+		})
+	}
+	return symbols
+}
+
+func lineRange(startLine, endLine int) map[string]interface{} {
+	return map[string]interface{}{
+		"start": map[string]int{"line": startLine - 1, "character": 0},
+		"end":   map[string]int{"line": endLine - 1, "character": 0},
+	}
+}
+
+type diagnostic struct {
+	Range    map[string]interface{} `json:"range"`
+	Severity int                     `json:"severity"`
+	Message  string                  `json:"message"`
+}
+
+func diagnosticRangeForWholeFile(text string) map[string]interface{} {
+	lines := strings.Split(text, "\n")
+	last := len(lines) - 1
+	if last < 0 {
+		last = 0
+	}
+	return map[string]interface{}{
+		"start": map[string]int{"line": 0, "character": 0},
+		"end":   map[string]int{"line": last, "character": utf16Len(lastLine(lines))},
+	}
+}
+
+func lastLine(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[len(lines)-1]
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// diagnosticsForParseResult flags invalid parameter names within each
+// TypeContext, and warns on any TypeContext with an empty Type.
+// analysis.ExtractInfo emits no TypeContext for an @type whose value cannot
+// be resolved, so such a field gets no warning here. text
+// is the document's full source, needed to convert ParameterInfo's byte-based
+// columns into LSP's UTF-16 character offsets. Schema-aware diagnostics (a
+// parameter that's merely not in the schema) are the job of the `validate`
+// subcommand; the LSP server only has enough information to catch
+// structurally obvious problems as the document is edited.
+func diagnosticsForParseResult(result analysis.ParseResult, text string) []diagnostic {
+	lines := strings.Split(text, "\n")
+
+	var diags []diagnostic
+	for _, ctx := range result.Contexts {
+		if ctx.Type == "" {
+			diags = append(diags, diagnostic{
+				Range:    lineRange(ctx.StartLine, ctx.EndLine),
+				Severity: 2, // Warning
+				Message:  "@type context has no resolvable type value",
+			})
+		}
+	}
+	for _, param := range result.Parameters {
+		if param.InvalidName {
+			diags = append(diags, diagnostic{
+				Range:    fieldRange(param, lines),
+				Severity: 1, // Error
+				Message:  fmt.Sprintf("%q is not a valid parameter name", param.Name),
+			})
+		}
+	}
+	return diags
+}
+
+// fieldRange converts a ParameterInfo's 1-based start/end line+column into
+// an LSP range. CUE's token.Pos columns are 1-based byte offsets into the
+// line, but LSP characters are UTF-16 code units, so a line with multibyte
+// runes before the field would otherwise shift the reported column. Re-
+// measure each column by re-encoding the line's byte-prefix up to that
+// column as UTF-16, the same approach diagnosticRangeForWholeFile uses.
+func fieldRange(param analysis.ParameterInfo, lines []string) map[string]interface{} {
+	return map[string]interface{}{
+		"start": utf16FieldPosition(lines, param.Line, param.Column),
+		"end":   utf16FieldPosition(lines, param.EndLine, param.EndColumn),
+	}
+}
+
+// utf16FieldPosition converts a 1-based (line, column) byte position into an
+// LSP 0-based (line, character) position, measuring character in UTF-16 code
+// units against the actual source line rather than assuming column is
+// already a character count.
+func utf16FieldPosition(lines []string, line, column int) map[string]int {
+	lineIndex := line - 1
+	byteOffset := column - 1
+
+	character := byteOffset
+	if lineIndex >= 0 && lineIndex < len(lines) {
+		lineText := lines[lineIndex]
+		if byteOffset < 0 {
+			byteOffset = 0
+		}
+		if byteOffset > len(lineText) {
+			byteOffset = len(lineText)
+		}
+		character = utf16Len(lineText[:byteOffset])
+	}
+
+	return map[string]int{"line": lineIndex, "character": character}
+}
@@ -0,0 +1,79 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/parser"
+
+	"github.com/ZettaAI/vscode-zutils/scripts/cue-parser/analysis"
+)
+
+func newTestServer(uri string, src string, result analysis.ParseResult, schema Schema) (*Server, *bytes.Buffer) {
+	var buf bytes.Buffer
+	s := &Server{
+		docs: map[string]*document{
+			uri: {uri: uri, text: src, result: result},
+		},
+		schema: schema,
+		out:    bufio.NewWriter(&buf),
+		log:    log.New(io.Discard, "", 0),
+	}
+	return s, &buf
+}
+
+func completionParams(uri string, line, character int) json.RawMessage {
+	params, _ := json.Marshal(map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     map[string]int{"line": line, "character": character},
+	})
+	return params
+}
+
+func TestHandleCompletion_UsesSchemaParameterNames(t *testing.T) {
+	src := "top: {\n\t\"@type\": \"Outer\"\n\t\"@version\": \"1.0.0\"\n}\n"
+	f, err := parser.ParseFile("test.cue", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	result := analysis.ExtractInfo(f)
+
+	schema := Schema{
+		"Outer@1.0.0": {Parameters: map[string]struct{}{"known_param": {}}},
+	}
+	s, buf := newTestServer("file:///test.cue", src, result, schema)
+
+	s.handleCompletion(&rpcMessage{
+		ID:     json.RawMessage(`1`),
+		Params: completionParams("file:///test.cue", 1, 1),
+	})
+
+	if !strings.Contains(buf.String(), "known_param") {
+		t.Fatalf("expected schema parameter %q in completion response, got %s", "known_param", buf.String())
+	}
+}
+
+func TestHandleCompletion_FallsBackToDocumentNamesWithoutSchema(t *testing.T) {
+	src := "top: {\n\t\"@type\": \"Outer\"\n\tdoc_param: \"x\"\n}\n"
+	f, err := parser.ParseFile("test.cue", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	result := analysis.ExtractInfo(f)
+
+	s, buf := newTestServer("file:///test.cue", src, result, nil)
+
+	s.handleCompletion(&rpcMessage{
+		ID:     json.RawMessage(`1`),
+		Params: completionParams("file:///test.cue", 1, 1),
+	})
+
+	if !strings.Contains(buf.String(), "doc_param") {
+		t.Fatalf("expected document-observed parameter %q in completion response, got %s", "doc_param", buf.String())
+	}
+}
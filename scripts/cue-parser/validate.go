@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"cuelang.org/go/cue/parser"
+
+	"github.com/ZettaAI/vscode-zutils/scripts/cue-parser/analysis"
+)
+
+// Schema maps "type@version" to the parameters that @type context allows.
+type Schema map[string]SchemaType
+
+// SchemaType describes the allowed parameters for one type@version.
+type SchemaType struct {
+	Parameters map[string]SchemaParameter `json:"parameters"`
+}
+
+// SchemaParameter describes one allowed parameter name.
+type SchemaParameter struct {
+	Required bool   `json:"required,omitempty"`
+	Type     string `json:"type,omitempty"`
+}
+
+// Severity mirrors the LSP DiagnosticSeverity values the VS Code extension
+// already understands.
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+)
+
+// Position is a 1-based line/column, matching ParameterInfo/TypeContext.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Range is a full start/end span, not just a start position, so a caller
+// can highlight the exact token a Diagnostic refers to.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic is one validate finding.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// runValidate implements the `validate` subcommand: parse a CUE file from
+// stdin, check its @type contexts and parameters against --schema, and
+// print the resulting diagnostics as JSON.
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "path to a schemas.json mapping \"type@version\" to allowed parameters (required)")
+	fs.Parse(args)
+
+	if *schemaPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: validate requires --schema")
+		os.Exit(1)
+	}
+
+	schema, err := loadSchema(*schemaPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading schema %s: %v\n", *schemaPath, err)
+		os.Exit(1)
+	}
+
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading from stdin: %v\n", err)
+		os.Exit(1)
+	}
+
+	f, err := parser.ParseFile("stdin", content, parser.ParseComments)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: CUE syntax errors detected, continuing with partial parsing: %v\n", err)
+		if f == nil {
+			fmt.Fprintf(os.Stderr, "Error: Complete parsing failure, no AST available\n")
+			os.Exit(1)
+		}
+	}
+
+	result := analysis.ExtractInfo(f)
+	diagnostics := validateParseResult(result, schema)
+
+	output, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(output))
+}
+
+func loadSchema(path string) (Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+	return schema, nil
+}
+
+func schemaKey(typ, version string) string {
+	return typ + "@" + version
+}
+
+// validateParseResult checks each TypeContext against schema: unknown
+// type@version combos, unknown parameter names, and missing required
+// parameters.
+func validateParseResult(result analysis.ParseResult, schema Schema) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	for ctxIndex, ctx := range result.Contexts {
+		// ctx.Type is only a name we can look up in the schema when it
+		// was written as a plain string literal. A selector (#Def,
+		// pkg.Type) or a more complex expression might still resolve to
+		// a known type at a later stage (e.g. once CUE evaluates it),
+		// so report that we deferred instead of flagging valid input as
+		// unknown.
+		if ctx.Kind != analysis.KindLiteral {
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    contextRange(ctx),
+				Severity: SeverityInformation,
+				Code:     "deferred-type",
+				Message:  fmt.Sprintf("@type is a %s, not a literal; skipping schema validation for it", ctx.Kind),
+			})
+			continue
+		}
+
+		key := schemaKey(ctx.Type, ctx.Version)
+		schemaType, known := schema[key]
+		if !known {
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    contextRange(ctx),
+				Severity: SeverityWarning,
+				Code:     "unknown-type",
+				Message:  fmt.Sprintf("%s is not present in the schema", key),
+			})
+			continue
+		}
+
+		seen := make(map[string]bool, len(schemaType.Parameters))
+		for _, param := range result.Parameters {
+			if param.ParentContext == nil || *param.ParentContext != ctxIndex {
+				continue
+			}
+			seen[param.Name] = true
+			if _, ok := schemaType.Parameters[param.Name]; !ok {
+				diagnostics = append(diagnostics, Diagnostic{
+					Range:    parameterRange(param),
+					Severity: SeverityError,
+					Code:     "unknown-parameter",
+					Message:  fmt.Sprintf("%q is not a known parameter of %s", param.Name, key),
+				})
+			}
+		}
+
+		for name, spec := range schemaType.Parameters {
+			if spec.Required && !seen[name] {
+				diagnostics = append(diagnostics, Diagnostic{
+					Range:    contextRange(ctx),
+					Severity: SeverityError,
+					Code:     "missing-required-parameter",
+					Message:  fmt.Sprintf("missing required parameter %q for %s", name, key),
+				})
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+func contextRange(ctx analysis.TypeContext) Range {
+	return Range{
+		Start: Position{Line: ctx.StartLine, Column: 1},
+		End:   Position{Line: ctx.EndLine, Column: 1},
+	}
+}
+
+func parameterRange(param analysis.ParameterInfo) Range {
+	return Range{
+		Start: Position{Line: param.Line, Column: param.Column},
+		End:   Position{Line: param.EndLine, Column: param.EndColumn},
+	}
+}
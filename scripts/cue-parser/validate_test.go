@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/parser"
+
+	"github.com/ZettaAI/vscode-zutils/scripts/cue-parser/analysis"
+)
+
+func TestValidateParseResult(t *testing.T) {
+	src := `
+top: {
+	"@type": "Foo"
+	"@version": "1.0.0"
+	known: "a"
+	extra: "b"
+}
+unknown_type: {
+	"@type": "Bar"
+}
+deferred_type: {
+	"@type": pkg.Type
+}
+`
+	f, err := parser.ParseFile("test.cue", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	result := analysis.ExtractInfo(f)
+
+	schema := Schema{
+		"Foo@1.0.0": {
+			Parameters: map[string]SchemaParameter{
+				"known":    {},
+				"required": {Required: true},
+			},
+		},
+	}
+
+	diags := validateParseResult(result, schema)
+
+	var codes []string
+	for _, d := range diags {
+		codes = append(codes, d.Code)
+	}
+
+	wantCodes := map[string]int{
+		"unknown-type":               1,
+		"unknown-parameter":          1,
+		"missing-required-parameter": 1,
+		"deferred-type":              1,
+	}
+	gotCodes := map[string]int{}
+	for _, c := range codes {
+		gotCodes[c]++
+	}
+	for code, want := range wantCodes {
+		if gotCodes[code] != want {
+			t.Errorf("code %s: got %d, want %d (diags: %+v)", code, gotCodes[code], want, diags)
+		}
+	}
+}